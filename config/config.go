@@ -0,0 +1,140 @@
+// Package config loads application settings from a config file (app.env or
+// config.yaml) with process environment variables layered on top, so the
+// same binary can be deployed with different settings per environment
+// instead of relying on hard-coded values.
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Config holds the settings Load reads at boot. STORE_BACKEND and
+// CONFIG_PATH are not part of it; they are read from the process
+// environment directly.
+type Config struct {
+	MongoURI        string
+	MongoDatabase   string
+	MongoCollection string
+	PostgresDSN     string
+	Port            string
+	RequestTimeout  time.Duration
+	ConnectTimeout  time.Duration
+
+	MongoTLSCAFile   string
+	MongoTLSCertFile string
+	MongoTLSKeyFile  string
+	MongoTLSInsecure bool
+
+	MongoSlowQueryThreshold time.Duration
+}
+
+// defaults are applied before the config file and environment are read, so
+// any value left unset below falls back to these.
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("port", "8080")
+	v.SetDefault("mongoDatabase", "test")
+	v.SetDefault("mongoCollection", "items")
+	v.SetDefault("requestTimeout", 5*time.Second)
+	v.SetDefault("connectTimeout", 10*time.Second)
+	v.SetDefault("mongoSlowQueryThreshold", 100*time.Millisecond)
+}
+
+// Load reads configuration from the file at path (app.env or config.yaml),
+// then overlays process environment variables, which always take priority.
+// If path is empty, it looks for app.env and then config.yaml in the
+// current directory; a missing config file is not an error since env vars
+// alone are a valid configuration. app.env keys may be written either in
+// the internal camelCase form (mongoURI=...) or the same SCREAMING_SNAKE
+// names used for process env vars (MONGO_URI=...); both are honored.
+func Load(path string) (*Config, error) {
+	v := viper.New()
+	setDefaults(v)
+
+	if path == "" {
+		path = firstExisting("app.env", "config.yaml")
+	}
+
+	if path != "" {
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("config: reading %s: %w", path, err)
+		}
+	}
+
+	v.AutomaticEnv()
+	mongoURIKey := bindEnv(v, "mongoURI", "MONGODB_URI", "MONGO_URI")
+	mongoDatabaseKey := bindEnv(v, "mongoDatabase", "MONGO_DATABASE")
+	mongoCollectionKey := bindEnv(v, "mongoCollection", "MONGO_COLLECTION")
+	postgresDSNKey := bindEnv(v, "postgresDSN", "POSTGRES_DSN")
+	portKey := bindEnv(v, "port", "PORT")
+	requestTimeoutKey := bindEnv(v, "requestTimeout", "REQUEST_TIMEOUT")
+	connectTimeoutKey := bindEnv(v, "connectTimeout", "CONNECT_TIMEOUT")
+	mongoTLSCAFileKey := bindEnv(v, "mongoTLSCAFile", "MONGO_TLS_CA_FILE")
+	mongoTLSCertFileKey := bindEnv(v, "mongoTLSCertFile", "MONGO_TLS_CERT_FILE")
+	mongoTLSKeyFileKey := bindEnv(v, "mongoTLSKeyFile", "MONGO_TLS_KEY_FILE")
+	mongoTLSInsecureKey := bindEnv(v, "mongoTLSInsecure", "MONGO_TLS_INSECURE")
+	mongoSlowQueryThresholdKey := bindEnv(v, "mongoSlowQueryThreshold", "MONGO_SLOW_QUERY_THRESHOLD")
+
+	cfg := &Config{
+		MongoURI:        v.GetString(mongoURIKey),
+		MongoDatabase:   v.GetString(mongoDatabaseKey),
+		MongoCollection: v.GetString(mongoCollectionKey),
+		PostgresDSN:     v.GetString(postgresDSNKey),
+		Port:            v.GetString(portKey),
+		RequestTimeout:  v.GetDuration(requestTimeoutKey),
+		ConnectTimeout:  v.GetDuration(connectTimeoutKey),
+
+		MongoTLSCAFile:   v.GetString(mongoTLSCAFileKey),
+		MongoTLSCertFile: v.GetString(mongoTLSCertFileKey),
+		MongoTLSKeyFile:  v.GetString(mongoTLSKeyFileKey),
+		MongoTLSInsecure: v.GetBool(mongoTLSInsecureKey),
+
+		MongoSlowQueryThreshold: v.GetDuration(mongoSlowQueryThresholdKey),
+	}
+
+	return cfg, nil
+}
+
+// bindEnv binds a config key to one or more environment variable names, in
+// priority order, ignoring the error viper returns only when no keys are
+// given (which never happens here). It returns the key callers should read
+// the value back from: normally the camelCase key itself, but viper stores
+// a dotenv/YAML key verbatim (lowercased) rather than under the camelCase
+// name BindEnv registers process-env overrides under, so a file written the
+// conventional way (e.g. MONGO_URI=... in app.env, matching every other env
+// var name in this series) would otherwise be silently dropped. If nothing
+// set the camelCase key (no process env, no camelCase file key; a default
+// does not count) but the file did set one of envNames, bindEnv returns
+// that key instead so the file value is still picked up.
+func bindEnv(v *viper.Viper, key string, envNames ...string) string {
+	_ = v.BindEnv(append([]string{key}, envNames...)...)
+
+	for _, name := range append([]string{strings.ToUpper(key)}, envNames...) {
+		if os.Getenv(name) != "" {
+			return key
+		}
+	}
+	if v.InConfig(key) {
+		return key
+	}
+	for _, name := range envNames {
+		if fileKey := strings.ToLower(name); v.InConfig(fileKey) {
+			return fileKey
+		}
+	}
+	return key
+}
+
+func firstExisting(paths ...string) string {
+	for _, p := range paths {
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
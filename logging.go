@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// requestIDHeader is the header clients may set to propagate their own
+// trace ID across services; one is generated when absent.
+const requestIDHeader = "X-Request-ID"
+
+// ctxKey namespaces context values set by this file so they can't collide
+// with keys set elsewhere in the package.
+type ctxKey int
+
+const (
+	requestIDKey ctxKey = iota
+	mongoOpCountKey
+)
+
+// newLogger builds the application-wide structured logger. Every line is
+// JSON so container log collectors (and `docker logs`) can index it.
+func newLogger() zerolog.Logger {
+	zerolog.TimeFieldFormat = time.RFC3339
+	return zerolog.New(os.Stdout).With().Timestamp().Logger()
+}
+
+// requestLoggerMiddleware emits one structured log line per request with
+// the fields required for observability in container platforms: method,
+// path, status, latency, client IP, request ID, and how many Mongo
+// commands the request issued. The request ID is read from X-Request-ID
+// when the caller sent one, otherwise generated, and echoed back on the
+// response so callers can correlate their logs with ours.
+func requestLoggerMiddleware(l zerolog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		reqID := c.GetHeader(requestIDHeader)
+		if reqID == "" {
+			reqID = generateRequestID()
+		}
+		c.Writer.Header().Set(requestIDHeader, reqID)
+
+		var opCount int64
+		ctx := context.WithValue(c.Request.Context(), requestIDKey, reqID)
+		ctx = context.WithValue(ctx, mongoOpCountKey, &opCount)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		l.Info().
+			Str("method", c.Request.Method).
+			Str("path", c.FullPath()).
+			Int("status", c.Writer.Status()).
+			Int64("latency_ms", time.Since(start).Milliseconds()).
+			Str("client_ip", c.ClientIP()).
+			Str("request_id", reqID).
+			Int64("mongo_ops", atomic.LoadInt64(&opCount)).
+			Msg("request handled")
+	}
+}
+
+// generateRequestID mints a short random trace ID, mirroring the
+// crypto/rand + hex pattern already used for bearer tokens in users.go.
+func generateRequestID() string {
+	raw := make([]byte, 8)
+	if _, err := rand.Read(raw); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(raw)
+}
+
+// incrMongoOpCount bumps the per-request Mongo op counter stashed in ctx by
+// requestLoggerMiddleware. It's a no-op outside a request (e.g. in tests
+// that call the store directly with context.Background()).
+func incrMongoOpCount(ctx context.Context) {
+	if counter, ok := ctx.Value(mongoOpCountKey).(*int64); ok {
+		atomic.AddInt64(counter, 1)
+	}
+}
+
+// mongoRequestID pulls the tracing request ID out of ctx, if any, so slow
+// query logs can be correlated with the request log line.
+func mongoRequestID(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIDKey).(string); ok {
+		return id
+	}
+	return ""
+}
+
+// mongoCommandMonitor implements the mongo driver's event.CommandMonitor,
+// logging commands that are slow or fail with the BSON command redacted of
+// values (only field names are kept) so logs never leak document contents.
+type mongoCommandMonitor struct {
+	logger        zerolog.Logger
+	slowThreshold time.Duration
+	pending       sync.Map // requestID (int64) -> startedCommand
+}
+
+type startedCommand struct {
+	name    string
+	command bson.Raw
+}
+
+// newMongoCommandMonitor returns an *event.CommandMonitor wired to l; any
+// command taking at least slowThreshold to complete, or that fails
+// outright, is logged.
+func newMongoCommandMonitor(l zerolog.Logger, slowThreshold time.Duration) *event.CommandMonitor {
+	m := &mongoCommandMonitor{logger: l, slowThreshold: slowThreshold}
+	return &event.CommandMonitor{
+		Started:   m.started,
+		Succeeded: m.succeeded,
+		Failed:    m.failed,
+	}
+}
+
+func (m *mongoCommandMonitor) started(ctx context.Context, evt *event.CommandStartedEvent) {
+	incrMongoOpCount(ctx)
+	m.pending.Store(evt.RequestID, startedCommand{
+		name:    evt.CommandName,
+		command: evt.Command,
+	})
+}
+
+func (m *mongoCommandMonitor) succeeded(ctx context.Context, evt *event.CommandSucceededEvent) {
+	m.finish(ctx, evt.RequestID, evt.Duration, nil)
+}
+
+func (m *mongoCommandMonitor) failed(ctx context.Context, evt *event.CommandFailedEvent) {
+	m.finish(ctx, evt.RequestID, evt.Duration, errors.New(fmt.Sprint(evt.Failure)))
+}
+
+func (m *mongoCommandMonitor) finish(ctx context.Context, requestID int64, duration time.Duration, cmdErr error) {
+	v, ok := m.pending.LoadAndDelete(requestID)
+	if !ok {
+		return
+	}
+	started := v.(startedCommand)
+
+	if cmdErr == nil && duration < m.slowThreshold {
+		return
+	}
+
+	ev := m.logger.Warn()
+	if cmdErr != nil {
+		ev = ev.Err(cmdErr)
+	}
+	ev.
+		Str("request_id", mongoRequestID(ctx)).
+		Str("command", started.name).
+		Interface("filter", redactMongoCommand(started.command)).
+		Dur("duration", duration).
+		Msg("slow or failed mongo command")
+}
+
+// redactMongoCommand copies cmd's shape (keys, nesting) while replacing
+// every leaf value with "?" so logs show what was queried, not the data.
+func redactMongoCommand(cmd bson.Raw) bson.M {
+	redacted := bson.M{}
+	if cmd == nil {
+		return redacted
+	}
+
+	elements, err := cmd.Elements()
+	if err != nil {
+		return redacted
+	}
+	for _, elem := range elements {
+		redacted[elem.Key()] = redactValue(elem.Value())
+	}
+	return redacted
+}
+
+func redactValue(v bson.RawValue) interface{} {
+	switch v.Type {
+	case bson.TypeEmbeddedDocument:
+		return redactMongoCommand(v.Document())
+	case bson.TypeArray:
+		elements, err := v.Array().Elements()
+		if err != nil {
+			return "?"
+		}
+		out := make([]interface{}, len(elements))
+		for i, elem := range elements {
+			out[i] = redactValue(elem.Value())
+		}
+		return out
+	default:
+		return "?"
+	}
+}
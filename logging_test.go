@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestRedactMongoCommand(t *testing.T) {
+	raw, err := bson.Marshal(bson.D{
+		{Key: "find", Value: "items"},
+		{Key: "filter", Value: bson.D{
+			{Key: "name", Value: "widget"},
+			{Key: "tags", Value: bson.A{"a", "b"}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("bson.Marshal: %v", err)
+	}
+
+	redacted := redactMongoCommand(bson.Raw(raw))
+
+	if redacted["find"] != "?" {
+		t.Fatalf(`redacted["find"] = %v, want "?"`, redacted["find"])
+	}
+
+	filter, ok := redacted["filter"].(bson.M)
+	if !ok {
+		t.Fatalf("redacted[\"filter\"] is %T, want bson.M", redacted["filter"])
+	}
+	if filter["name"] != "?" {
+		t.Fatalf(`filter["name"] = %v, want "?"`, filter["name"])
+	}
+
+	tags, ok := filter["tags"].([]interface{})
+	if !ok || len(tags) != 2 || tags[0] != "?" || tags[1] != "?" {
+		t.Fatalf(`filter["tags"] = %v, want ["?", "?"]`, filter["tags"])
+	}
+}
@@ -2,80 +2,83 @@ package main
 
 import (
 	"context"
-	"log"
+	"errors"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv" // ← ADD THIS
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"github.com/rs/zerolog"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/umair-fa22/golan-mongodb-docker/config"
 )
 
+// shutdownGracePeriod bounds how long in-flight requests get to finish once
+// a shutdown signal arrives before the server hangs up on them anyway.
+const shutdownGracePeriod = 10 * time.Second
+
+// readyzTimeout bounds the Mongo ping /readyz does on every call; it must
+// stay well under whatever probe timeout k8s is configured with.
+const readyzTimeout = 2 * time.Second
+
 // Item model
 type Item struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Name      string             `bson:"name" json:"name"`
-	UnitPrice float64            `bson:"unitPrice" json:"unitPrice"`
-	Quantity  int                `bson:"quantity" json:"quantity"`
+	ID        string  `json:"id,omitempty"`
+	Name      string  `json:"name"`
+	UnitPrice float64 `json:"unitPrice"`
+	Quantity  int     `json:"quantity"`
 }
 
 // MongoDB client
 var client *mongo.Client
-var collection *mongo.Collection
 
-func main() {
-	// === LOAD .env (only if present) ===
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found - using system environment variables")
-	}
+// store is the active ItemStore backend, selected at boot by STORE_BACKEND.
+var store ItemStore
 
-	// === GET CONFIG ===
-	// Support either MONGODB_URI (preferred) or legacy MONGO_URI
-	uri := os.Getenv("MONGODB_URI")
-	if uri == "" {
-		uri = os.Getenv("MONGO_URI")
-	}
-	log.Println("MONGODB_URI ->", uri)
+// cfg holds the application settings loaded at boot.
+var cfg *config.Config
 
-	if uri == "" {
-		log.Fatal("MONGODB_URI (or MONGO_URI) is required (set in .env or environment)")
-	}
+// logger is the application-wide structured logger, set up first thing in
+// main so every log line (including boot errors) goes through it.
+var logger zerolog.Logger
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-
-	// === CONNECT TO MONGODB ===
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+func main() {
+	logger = newLogger()
 
+	// === LOAD CONFIG (app.env / config.yaml, overlaid with process env) ===
 	var err error
-	client, err = mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	cfg, err = config.Load(os.Getenv("CONFIG_PATH"))
 	if err != nil {
-		log.Fatal("MongoDB connection error:", err)
+		logger.Fatal().Err(err).Msg("loading config")
 	}
-	defer func() {
-		if err := client.Disconnect(ctx); err != nil {
-			log.Println("Error disconnecting from MongoDB:", err)
-		}
-	}()
 
-	// Verify connection
-	if err = client.Ping(ctx, nil); err != nil {
-		log.Fatal("MongoDB ping failed:", err)
+	// === SELECT STORAGE BACKEND ===
+	backend := os.Getenv("STORE_BACKEND")
+	if backend == "" {
+		backend = "mongo"
 	}
-	log.Println("Connected to MongoDB →", uri)
 
-	// Use 'test' database and 'items' collection
-	collection = client.Database("test").Collection("items")
+	switch backend {
+	case "mongo":
+		store = mustMongoStore()
+	case "postgres":
+		store = mustPostgresStore()
+	default:
+		logger.Fatal().Str("backend", backend).Msg("unknown STORE_BACKEND (expected mongo or postgres)")
+	}
+
+	// === AUTH SETUP (users always live in MongoDB, regardless of STORE_BACKEND) ===
+	users := newUserStore(mustMongoClient().Database(cfg.MongoDatabase).Collection("users"))
 
 	// === GIN SETUP ===
-	r := gin.Default()
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(requestLoggerMiddleware(logger))
 	r.Static("/static", "./static")
 	r.LoadHTMLGlob("static/*.html")
 
@@ -83,72 +86,250 @@ func main() {
 		c.HTML(http.StatusOK, "index.html", nil)
 	})
 
-	// API Routes
+	r.POST("/auth/register", registerHandler(users))
+	r.POST("/auth/token", authMiddleware(users), tokenHandler(users))
+
+	// API Routes: GET is public, mutating verbs require a bearer token.
 	api := r.Group("/api")
 	{
 		api.GET("/items", getItems)
 		api.GET("/items/:id", getItem)
-		api.POST("/items", createItem)
-		api.PUT("/items/:id", updateItem)
-		api.DELETE("/items/:id", deleteItem)
+		api.POST("/items", authMiddleware(users), createItem)
+		api.PUT("/items/:id", authMiddleware(users), updateItem)
+		api.DELETE("/items/:id", authMiddleware(users), deleteItem)
 	}
 
-	// Health endpoint for container orchestration and healthchecks
-	r.GET("/health", func(c *gin.Context) {
+	// Liveness: the process is up and can handle HTTP traffic at all. Always
+	// 200 so k8s doesn't restart the pod over a transient dependency blip.
+	r.GET("/livez", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
+	// Readiness: can this instance actually serve traffic right now? A
+	// failed Mongo ping takes the pod out of the load balancer without
+	// killing it, so it can rejoin once the blip passes.
+	r.GET("/readyz", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), readyzTimeout)
+		defer cancel()
+
+		if err := client.Ping(ctx, nil); err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "down", "mongo": "down", "error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "mongo": "up"})
+	})
+
 	// === START SERVER ===
-	log.Printf("Server starting on :%s", port)
+	srv := &http.Server{
+		Addr:    ":" + cfg.Port,
+		Handler: r,
+	}
 
-	// "try/catch" style: recover from panics and handle Run error
-	defer func() {
-		if rec := recover(); rec != nil {
-			log.Printf("Recovered from panic while starting server: %v", rec)
+	go func() {
+		logger.Info().Str("port", cfg.Port).Msg("server starting")
+
+		// "try/catch" style: recover from panics while serving
+		defer func() {
+			if rec := recover(); rec != nil {
+				logger.Error().Interface("panic", rec).Msg("recovered from panic while starting server")
+			}
+		}()
+
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Fatal().Err(err).Msg("server failed to start")
 		}
 	}()
 
-	if err := r.Run(":" + port); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
+	// === GRACEFUL SHUTDOWN ===
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	logger.Info().Msg("shutdown signal received")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownGracePeriod)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Error().Err(err).Msg("server shutdown error")
+	}
+	if client != nil {
+		disconnectCtx, disconnectCancel := context.WithTimeout(context.Background(), shutdownGracePeriod)
+		defer disconnectCancel()
+		if err := client.Disconnect(disconnectCtx); err != nil {
+			logger.Error().Err(err).Msg("mongo disconnect error")
+		}
 	}
+	logger.Info().Msg("server stopped")
+}
+
+// mustMongoClient connects to MongoDB using cfg.MongoURI, caching the client
+// in the package-level `client` var so callers that need Mongo for
+// something other than the item store (e.g. auth) can reuse the connection.
+func mustMongoClient() *mongo.Client {
+	if client != nil {
+		return client
+	}
+
+	if cfg.MongoURI == "" {
+		logger.Fatal().Msg("MongoURI is required (set MONGODB_URI, MONGO_URI, or the mongoURI config key)")
+	}
+	logger.Info().Str("mongoURI", cfg.MongoURI).Msg("connecting to MongoDB")
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+	defer cancel()
+
+	clientOpts := options.Client().
+		ApplyURI(cfg.MongoURI).
+		SetMonitor(newMongoCommandMonitor(logger, cfg.MongoSlowQueryThreshold))
+
+	tlsConfig, err := mongoTLSConfig(cfg)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("MongoDB TLS config error")
+	}
+	if tlsConfig != nil {
+		clientOpts.SetTLSConfig(tlsConfig)
+	}
+
+	client, err = mongo.Connect(ctx, clientOpts)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("MongoDB connection error")
+	}
+
+	if err = client.Ping(ctx, nil); err != nil {
+		logger.Fatal().Err(err).Msg("MongoDB ping failed")
+	}
+	logger.Info().Msg("connected to MongoDB")
+
+	return client
+}
+
+// mustMongoStore returns a store backed by cfg.MongoDatabase / cfg.MongoCollection.
+func mustMongoStore() *mongoStore {
+	collection := mustMongoClient().Database(cfg.MongoDatabase).Collection(cfg.MongoCollection)
+	return newMongoStore(collection)
+}
+
+// mustPostgresStore opens a Postgres connection using cfg.PostgresDSN and
+// returns a store backed by an auto-created 'items' table.
+func mustPostgresStore() *postgresStore {
+	if cfg.PostgresDSN == "" {
+		logger.Fatal().Msg("PostgresDSN is required when STORE_BACKEND=postgres")
+	}
+
+	s, err := newPostgresStore(cfg.PostgresDSN, cfg.ConnectTimeout)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Postgres connection error")
+	}
+	logger.Info().Msg("connected to Postgres")
+	return s
 }
 
 // === REST OF YOUR CRUD HANDLERS (unchanged) ===
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+// getItems supports pagination (limit/offset), sorting (sort/order),
+// substring search on name (q), and price bounds (minPrice/maxPrice).
 func getItems(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	opts := ListOptions{Limit: defaultListLimit, Sort: "name", Order: "asc"}
+
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxListLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+			return
+		}
+		opts.Limit = n
+	}
+
+	if v := c.Query("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset must be >= 0"})
+			return
+		}
+		opts.Offset = n
+	}
+
+	if v := c.Query("sort"); v != "" {
+		if !listSortFields[v] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be one of name, unitPrice, quantity"})
+			return
+		}
+		opts.Sort = v
+	}
+
+	if v := c.Query("order"); v != "" {
+		if v != "asc" && v != "desc" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "order must be asc or desc"})
+			return
+		}
+		opts.Order = v
+	}
+
+	opts.Query = c.Query("q")
+
+	if v := c.Query("minPrice"); v != "" {
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "minPrice must be a non-negative number"})
+			return
+		}
+		opts.MinPrice = &n
+	}
+
+	if v := c.Query("maxPrice"); v != "" {
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "maxPrice must be a non-negative number"})
+			return
+		}
+		opts.MaxPrice = &n
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.RequestTimeout)
 	defer cancel()
 
-	cursor, err := collection.Find(ctx, bson.M{})
+	items, total, err := store.List(ctx, opts)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	defer cursor.Close(ctx)
 
-	var items []Item
-	if err = cursor.All(ctx, &items); err != nil {
+	c.JSON(http.StatusOK, gin.H{
+		"items":  items,
+		"total":  total,
+		"limit":  opts.Limit,
+		"offset": opts.Offset,
+	})
+}
+
+// writeStoreError maps an ItemStore error to the right HTTP status: a
+// malformed ID is the caller's fault (400), a missing item is a normal miss
+// (404), and anything else is a genuine backend failure (500) - a DB outage
+// should never be reported to the client as a bad request.
+func writeStoreError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+	case errors.Is(err, ErrInvalidID):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+	default:
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
 	}
-
-	c.JSON(http.StatusOK, items)
 }
 
 func getItem(c *gin.Context) {
 	id := c.Param("id")
-	objID, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
-		return
-	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.RequestTimeout)
 	defer cancel()
 
-	var item Item
-	err = collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&item)
+	item, err := store.Get(ctx, id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		writeStoreError(c, err)
 		return
 	}
 
@@ -167,26 +348,20 @@ func createItem(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.RequestTimeout)
 	defer cancel()
 
-	result, err := collection.InsertOne(ctx, item)
+	created, err := store.Create(ctx, item)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	item.ID = result.InsertedID.(primitive.ObjectID)
-	c.JSON(http.StatusCreated, item)
+	c.JSON(http.StatusCreated, created)
 }
 
 func updateItem(c *gin.Context) {
 	id := c.Param("id")
-	objID, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
-		return
-	}
 
 	var item Item
 	if err := c.ShouldBindJSON(&item); err != nil {
@@ -199,51 +374,26 @@ func updateItem(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.RequestTimeout)
 	defer cancel()
 
-	update := bson.M{
-		"$set": bson.M{
-			"name":      item.Name,
-			"unitPrice": item.UnitPrice,
-			"quantity":  item.Quantity,
-		},
-	}
-
-	result, err := collection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+	updated, err := store.Update(ctx, id, item)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	if result.MatchedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		writeStoreError(c, err)
 		return
 	}
 
-	item.ID = objID
-	c.JSON(http.StatusOK, item)
+	c.JSON(http.StatusOK, updated)
 }
 
 func deleteItem(c *gin.Context) {
 	id := c.Param("id")
-	objID, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
-		return
-	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.RequestTimeout)
 	defer cancel()
 
-	result, err := collection.DeleteOne(ctx, bson.M{"_id": objID})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	if result.DeletedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+	if err := store.Delete(ctx, id); err != nil {
+		writeStoreError(c, err)
 		return
 	}
 
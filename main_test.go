@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/umair-fa22/golan-mongodb-docker/config"
+)
+
+// TestMain sets up the package-level globals the handlers under test read
+// directly (cfg, logger), the same way main() would at boot.
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	cfg = &config.Config{RequestTimeout: 2 * time.Second}
+	logger = newLogger()
+	m.Run()
+}
+
+// stubStore is an in-memory ItemStore double used to test the HTTP layer
+// (query param validation, error-to-status mapping) without a real backend.
+type stubStore struct {
+	listOpts ListOptions
+	items    []Item
+	total    int64
+	listErr  error
+
+	getErr    error
+	item      Item
+	updateErr error
+	deleteErr error
+}
+
+func (s *stubStore) List(ctx context.Context, opts ListOptions) ([]Item, int64, error) {
+	s.listOpts = opts
+	return s.items, s.total, s.listErr
+}
+
+func (s *stubStore) Get(ctx context.Context, id string) (Item, error) {
+	return s.item, s.getErr
+}
+
+func (s *stubStore) Create(ctx context.Context, item Item) (Item, error) {
+	return item, nil
+}
+
+func (s *stubStore) Update(ctx context.Context, id string, item Item) (Item, error) {
+	return item, s.updateErr
+}
+
+func (s *stubStore) Delete(ctx context.Context, id string) error {
+	return s.deleteErr
+}
+
+func TestGetItemsValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantOpts   ListOptions // only checked when wantStatus == 200
+	}{
+		{"defaults", "", http.StatusOK, ListOptions{Limit: defaultListLimit, Sort: "name", Order: "asc"}},
+		{"limit too high", "limit=501", http.StatusBadRequest, ListOptions{}},
+		{"limit zero", "limit=0", http.StatusBadRequest, ListOptions{}},
+		{"limit not a number", "limit=abc", http.StatusBadRequest, ListOptions{}},
+		{"negative offset", "offset=-1", http.StatusBadRequest, ListOptions{}},
+		{"unknown sort field", "sort=bogus", http.StatusBadRequest, ListOptions{}},
+		{"bad order", "order=sideways", http.StatusBadRequest, ListOptions{}},
+		{"negative minPrice", "minPrice=-5", http.StatusBadRequest, ListOptions{}},
+		{"negative maxPrice", "maxPrice=-5", http.StatusBadRequest, ListOptions{}},
+		{
+			"full valid set",
+			"limit=10&offset=20&sort=unitPrice&order=desc&q=widget&minPrice=1&maxPrice=9",
+			http.StatusOK,
+			ListOptions{Limit: 10, Offset: 20, Sort: "unitPrice", Order: "desc", Query: "widget"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store = &stubStore{}
+
+			r := gin.New()
+			r.GET("/api/items", getItems)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/items?"+tt.query, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			got := store.(*stubStore).listOpts
+			if got.Limit != tt.wantOpts.Limit || got.Offset != tt.wantOpts.Offset ||
+				got.Sort != tt.wantOpts.Sort || got.Order != tt.wantOpts.Order || got.Query != tt.wantOpts.Query {
+				t.Fatalf("listOpts = %+v, want %+v", got, tt.wantOpts)
+			}
+		})
+	}
+}
+
+func TestItemHandlersMapStoreErrorsToStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{"not found", ErrNotFound, http.StatusNotFound},
+		{"invalid id", ErrInvalidID, http.StatusBadRequest},
+		{"backend failure", errors.New("connection refused"), http.StatusInternalServerError},
+		{"nil error", nil, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run("getItem/"+tt.name, func(t *testing.T) {
+			store = &stubStore{getErr: tt.err, item: Item{ID: "1", Name: "widget"}}
+
+			r := gin.New()
+			r.GET("/api/items/:id", getItem)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/items/1", nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+
+		t.Run("updateItem/"+tt.name, func(t *testing.T) {
+			store = &stubStore{updateErr: tt.err}
+
+			r := gin.New()
+			r.PUT("/api/items/:id", updateItem)
+
+			body, _ := json.Marshal(Item{Name: "widget", UnitPrice: 1, Quantity: 1})
+			req := httptest.NewRequest(http.MethodPut, "/api/items/1", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+
+		t.Run("deleteItem/"+tt.name, func(t *testing.T) {
+			store = &stubStore{deleteErr: tt.err}
+
+			r := gin.New()
+			r.DELETE("/api/items/:id", deleteItem)
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/items/1", nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
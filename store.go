@@ -0,0 +1,380 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrNotFound is returned by an ItemStore when the requested item does not exist.
+var ErrNotFound = errors.New("item not found")
+
+// ErrInvalidID is returned by an ItemStore when the given id isn't a valid
+// identifier for that backend (e.g. not a hex ObjectID for Mongo, not an
+// integer for Postgres), as distinct from a backend failure - callers use
+// this to tell a 400 (bad request) from a 500 (something actually broke).
+var ErrInvalidID = errors.New("invalid id")
+
+// ItemStore abstracts the CRUD operations the API needs so that the same
+// handlers can run against either MongoDB or Postgres, selected at boot via
+// STORE_BACKEND.
+type ItemStore interface {
+	List(ctx context.Context, opts ListOptions) ([]Item, int64, error)
+	Get(ctx context.Context, id string) (Item, error)
+	Create(ctx context.Context, item Item) (Item, error)
+	Update(ctx context.Context, id string, item Item) (Item, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// listSortFields whitelists the fields List can sort by; these are the same
+// names used in the JSON response and in Mongo's bson tags.
+var listSortFields = map[string]bool{
+	"name":      true,
+	"unitPrice": true,
+	"quantity":  true,
+}
+
+// ListOptions carries the validated query params for a paginated, filtered
+// List call. Sort must be a key of listSortFields and Order must be "asc"
+// or "desc"; callers (i.e. getItems) are responsible for validating and
+// defaulting these before building a ListOptions.
+type ListOptions struct {
+	Limit    int
+	Offset   int
+	Sort     string
+	Order    string
+	Query    string
+	MinPrice *float64
+	MaxPrice *float64
+}
+
+// === MongoDB-backed store ===
+
+// mongoItem is the BSON-facing shape stored in the items collection; Item is
+// the JSON-facing shape returned by the API. The two diverge only in how the
+// ID is represented (ObjectID vs. string), so the store converts at the edge.
+type mongoItem struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Name      string             `bson:"name"`
+	UnitPrice float64            `bson:"unitPrice"`
+	Quantity  int                `bson:"quantity"`
+}
+
+func (m mongoItem) toItem() Item {
+	return Item{
+		ID:        m.ID.Hex(),
+		Name:      m.Name,
+		UnitPrice: m.UnitPrice,
+		Quantity:  m.Quantity,
+	}
+}
+
+type mongoStore struct {
+	collection *mongo.Collection
+}
+
+func newMongoStore(collection *mongo.Collection) *mongoStore {
+	return &mongoStore{collection: collection}
+}
+
+func (s *mongoStore) List(ctx context.Context, opts ListOptions) ([]Item, int64, error) {
+	filter := bson.M{}
+	if opts.Query != "" {
+		// QuoteMeta escapes regex metacharacters so a caller can't submit a
+		// pathological pattern (e.g. nested quantifiers) and trigger
+		// catastrophic backtracking on this public, unauthenticated endpoint.
+		filter["name"] = bson.M{"$regex": regexp.QuoteMeta(opts.Query), "$options": "i"}
+	}
+	if opts.MinPrice != nil || opts.MaxPrice != nil {
+		price := bson.M{}
+		if opts.MinPrice != nil {
+			price["$gte"] = *opts.MinPrice
+		}
+		if opts.MaxPrice != nil {
+			price["$lte"] = *opts.MaxPrice
+		}
+		filter["unitPrice"] = price
+	}
+
+	total, err := s.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sortDir := 1
+	if opts.Order == "desc" {
+		sortDir = -1
+	}
+	findOpts := options.Find().
+		SetLimit(int64(opts.Limit)).
+		SetSkip(int64(opts.Offset)).
+		SetSort(bson.D{{Key: opts.Sort, Value: sortDir}})
+
+	cursor, err := s.collection.Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []mongoItem
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, 0, err
+	}
+
+	items := make([]Item, len(docs))
+	for i, d := range docs {
+		items[i] = d.toItem()
+	}
+	return items, total, nil
+}
+
+func (s *mongoStore) Get(ctx context.Context, id string) (Item, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return Item{}, ErrInvalidID
+	}
+
+	var doc mongoItem
+	if err := s.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Item{}, ErrNotFound
+		}
+		return Item{}, err
+	}
+	return doc.toItem(), nil
+}
+
+func (s *mongoStore) Create(ctx context.Context, item Item) (Item, error) {
+	doc := mongoItem{Name: item.Name, UnitPrice: item.UnitPrice, Quantity: item.Quantity}
+	result, err := s.collection.InsertOne(ctx, doc)
+	if err != nil {
+		return Item{}, err
+	}
+	doc.ID = result.InsertedID.(primitive.ObjectID)
+	return doc.toItem(), nil
+}
+
+func (s *mongoStore) Update(ctx context.Context, id string, item Item) (Item, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return Item{}, ErrInvalidID
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"name":      item.Name,
+			"unitPrice": item.UnitPrice,
+			"quantity":  item.Quantity,
+		},
+	}
+
+	result, err := s.collection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+	if err != nil {
+		return Item{}, err
+	}
+	if result.MatchedCount == 0 {
+		return Item{}, ErrNotFound
+	}
+
+	item.ID = objID.Hex()
+	return item, nil
+}
+
+func (s *mongoStore) Delete(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+
+	result, err := s.collection.DeleteOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// === Postgres-backed store ===
+
+type postgresStore struct {
+	db *sql.DB
+}
+
+// newPostgresStore opens the Postgres connection and makes sure the items
+// table exists before handing back a store.
+func newPostgresStore(dsn string, connectTimeout time.Duration) (*postgresStore, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	const createTable = `
+		CREATE TABLE IF NOT EXISTS items (
+			id SERIAL PRIMARY KEY,
+			name TEXT NOT NULL,
+			unit_price DOUBLE PRECISION NOT NULL,
+			quantity INTEGER NOT NULL
+		)`
+	if _, err := db.ExecContext(ctx, createTable); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &postgresStore{db: db}, nil
+}
+
+// postgresSortColumns maps the whitelisted JSON sort names to their Postgres
+// column names.
+var postgresSortColumns = map[string]string{
+	"name":      "name",
+	"unitPrice": "unit_price",
+	"quantity":  "quantity",
+}
+
+func (s *postgresStore) List(ctx context.Context, opts ListOptions) ([]Item, int64, error) {
+	where := []string{"TRUE"}
+	args := []interface{}{}
+
+	if opts.Query != "" {
+		args = append(args, "%"+opts.Query+"%")
+		where = append(where, fmt.Sprintf("name ILIKE $%d", len(args)))
+	}
+	if opts.MinPrice != nil {
+		args = append(args, *opts.MinPrice)
+		where = append(where, fmt.Sprintf("unit_price >= $%d", len(args)))
+	}
+	if opts.MaxPrice != nil {
+		args = append(args, *opts.MaxPrice)
+		where = append(where, fmt.Sprintf("unit_price <= $%d", len(args)))
+	}
+	whereClause := strings.Join(where, " AND ")
+
+	var total int64
+	countQuery := "SELECT COUNT(*) FROM items WHERE " + whereClause
+	if err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	order := "ASC"
+	if opts.Order == "desc" {
+		order = "DESC"
+	}
+	query := fmt.Sprintf(
+		"SELECT id, name, unit_price, quantity FROM items WHERE %s ORDER BY %s %s LIMIT %d OFFSET %d",
+		whereClause, postgresSortColumns[opts.Sort], order, opts.Limit, opts.Offset)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	items := []Item{}
+	for rows.Next() {
+		var id int
+		var item Item
+		if err := rows.Scan(&id, &item.Name, &item.UnitPrice, &item.Quantity); err != nil {
+			return nil, 0, err
+		}
+		item.ID = strconv.Itoa(id)
+		items = append(items, item)
+	}
+	return items, total, rows.Err()
+}
+
+func (s *postgresStore) Get(ctx context.Context, id string) (Item, error) {
+	intID, err := strconv.Atoi(id)
+	if err != nil {
+		return Item{}, ErrInvalidID
+	}
+
+	var item Item
+	row := s.db.QueryRowContext(ctx, "SELECT name, unit_price, quantity FROM items WHERE id = $1", intID)
+	if err := row.Scan(&item.Name, &item.UnitPrice, &item.Quantity); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Item{}, ErrNotFound
+		}
+		return Item{}, err
+	}
+	item.ID = id
+	return item, nil
+}
+
+func (s *postgresStore) Create(ctx context.Context, item Item) (Item, error) {
+	var id int
+	row := s.db.QueryRowContext(ctx,
+		"INSERT INTO items (name, unit_price, quantity) VALUES ($1, $2, $3) RETURNING id",
+		item.Name, item.UnitPrice, item.Quantity)
+	if err := row.Scan(&id); err != nil {
+		return Item{}, err
+	}
+	item.ID = strconv.Itoa(id)
+	return item, nil
+}
+
+func (s *postgresStore) Update(ctx context.Context, id string, item Item) (Item, error) {
+	intID, err := strconv.Atoi(id)
+	if err != nil {
+		return Item{}, ErrInvalidID
+	}
+
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE items SET name = $1, unit_price = $2, quantity = $3 WHERE id = $4",
+		item.Name, item.UnitPrice, item.Quantity, intID)
+	if err != nil {
+		return Item{}, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return Item{}, err
+	}
+	if affected == 0 {
+		return Item{}, ErrNotFound
+	}
+
+	item.ID = id
+	return item, nil
+}
+
+func (s *postgresStore) Delete(ctx context.Context, id string) error {
+	intID, err := strconv.Atoi(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM items WHERE id = $1", intID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
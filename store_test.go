@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// === mongoStore: id parsing happens before any collection access, so these
+// cases are exercised with no live connection. ===
+
+func TestMongoItemToItem(t *testing.T) {
+	id := primitive.NewObjectID()
+	doc := mongoItem{ID: id, Name: "widget", UnitPrice: 9.99, Quantity: 3}
+
+	item := doc.toItem()
+	if item.ID != id.Hex() || item.Name != "widget" || item.UnitPrice != 9.99 || item.Quantity != 3 {
+		t.Fatalf("toItem() = %+v, want ID=%s Name=widget UnitPrice=9.99 Quantity=3", item, id.Hex())
+	}
+}
+
+func TestMongoStoreInvalidID(t *testing.T) {
+	s := &mongoStore{} // no collection: Get/Update/Delete must fail before touching it
+
+	if _, err := s.Get(context.Background(), "not-a-valid-hex-id"); !errors.Is(err, ErrInvalidID) {
+		t.Fatalf("Get: err = %v, want ErrInvalidID", err)
+	}
+	if _, err := s.Update(context.Background(), "not-a-valid-hex-id", Item{}); !errors.Is(err, ErrInvalidID) {
+		t.Fatalf("Update: err = %v, want ErrInvalidID", err)
+	}
+	if err := s.Delete(context.Background(), "not-a-valid-hex-id"); !errors.Is(err, ErrInvalidID) {
+		t.Fatalf("Delete: err = %v, want ErrInvalidID", err)
+	}
+}
+
+// === postgresStore ===
+
+func newMockPostgresStore(t *testing.T) (*postgresStore, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return &postgresStore{db: db}, mock
+}
+
+func TestPostgresStoreInvalidID(t *testing.T) {
+	s, _ := newMockPostgresStore(t)
+
+	if _, err := s.Get(context.Background(), "not-a-number"); !errors.Is(err, ErrInvalidID) {
+		t.Fatalf("Get: err = %v, want ErrInvalidID", err)
+	}
+	if _, err := s.Update(context.Background(), "not-a-number", Item{}); !errors.Is(err, ErrInvalidID) {
+		t.Fatalf("Update: err = %v, want ErrInvalidID", err)
+	}
+	if err := s.Delete(context.Background(), "not-a-number"); !errors.Is(err, ErrInvalidID) {
+		t.Fatalf("Delete: err = %v, want ErrInvalidID", err)
+	}
+}
+
+func TestPostgresStoreGet(t *testing.T) {
+	s, mock := newMockPostgresStore(t)
+
+	rows := sqlmock.NewRows([]string{"name", "unit_price", "quantity"}).
+		AddRow("widget", 9.99, 3)
+	mock.ExpectQuery("SELECT name, unit_price, quantity FROM items WHERE id = \\$1").
+		WithArgs(42).
+		WillReturnRows(rows)
+
+	item, err := s.Get(context.Background(), "42")
+	if err != nil {
+		t.Fatalf("Get: unexpected error %v", err)
+	}
+	if item.ID != "42" || item.Name != "widget" || item.UnitPrice != 9.99 || item.Quantity != 3 {
+		t.Fatalf("Get: item = %+v", item)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestPostgresStoreGetNotFound(t *testing.T) {
+	s, mock := newMockPostgresStore(t)
+
+	mock.ExpectQuery("SELECT name, unit_price, quantity FROM items WHERE id = \\$1").
+		WithArgs(42).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "unit_price", "quantity"}))
+
+	if _, err := s.Get(context.Background(), "42"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestPostgresStoreCreate(t *testing.T) {
+	s, mock := newMockPostgresStore(t)
+
+	mock.ExpectQuery("INSERT INTO items").
+		WithArgs("widget", 9.99, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	item, err := s.Create(context.Background(), Item{Name: "widget", UnitPrice: 9.99, Quantity: 3})
+	if err != nil {
+		t.Fatalf("Create: unexpected error %v", err)
+	}
+	if item.ID != "7" {
+		t.Fatalf("Create: item.ID = %q, want 7", item.ID)
+	}
+}
+
+func TestPostgresStoreUpdateNotFound(t *testing.T) {
+	s, mock := newMockPostgresStore(t)
+
+	mock.ExpectExec("UPDATE items SET").
+		WithArgs("widget", 9.99, 3, 42).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if _, err := s.Update(context.Background(), "42", Item{Name: "widget", UnitPrice: 9.99, Quantity: 3}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Update: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestPostgresStoreList(t *testing.T) {
+	s, mock := newMockPostgresStore(t)
+
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM items WHERE").
+		WithArgs("%widget%").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	mock.ExpectQuery("SELECT id, name, unit_price, quantity FROM items WHERE").
+		WithArgs("%widget%").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "unit_price", "quantity"}).
+			AddRow(1, "widget", 9.99, 3))
+
+	items, total, err := s.List(context.Background(), ListOptions{
+		Limit: 50, Sort: "name", Order: "asc", Query: "widget",
+	})
+	if err != nil {
+		t.Fatalf("List: unexpected error %v", err)
+	}
+	if total != 1 || len(items) != 1 || items[0].Name != "widget" {
+		t.Fatalf("List: items=%+v total=%d", items, total)
+	}
+}
+
+func TestPostgresStoreDelete(t *testing.T) {
+	s, mock := newMockPostgresStore(t)
+
+	mock.ExpectExec("DELETE FROM items WHERE id = \\$1").
+		WithArgs(42).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := s.Delete(context.Background(), "42"); err != nil {
+		t.Fatalf("Delete: unexpected error %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/umair-fa22/golan-mongodb-docker/config"
+)
+
+// mongoTLSConfig builds a *tls.Config for the MongoDB driver from cfg, or
+// returns nil if no TLS options were configured (plain connections keep
+// working as before). A CA file lets the driver trust managed deployments
+// (Atlas, self-hosted replica sets) that don't use a public CA, and the
+// cert/key pair enables mTLS.
+func mongoTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	if cfg.MongoTLSCAFile == "" && cfg.MongoTLSCertFile == "" && cfg.MongoTLSKeyFile == "" && !cfg.MongoTLSInsecure {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.MongoTLSInsecure}
+
+	if cfg.MongoTLSCAFile != "" {
+		pem, err := os.ReadFile(cfg.MongoTLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading MONGO_TLS_CA_FILE: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in MONGO_TLS_CA_FILE %s", cfg.MongoTLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.MongoTLSCertFile != "" || cfg.MongoTLSKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.MongoTLSCertFile, cfg.MongoTLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading MONGO_TLS_CERT_FILE/MONGO_TLS_KEY_FILE: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrEmailTaken is returned by userStore.create when the email is already
+// registered.
+var ErrEmailTaken = errors.New("email already registered")
+
+// User is a registered API caller. TokenHash is the bcrypt hash of the
+// bearer token minted for the user; the raw token is never persisted.
+// TokenLookupHash is a sha256 hash of the same token, used only to find the
+// candidate user by an indexed exact match - bcrypt is deliberately slow and
+// can't be queried on, so authenticate still confirms the match with it.
+type User struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Email           string             `bson:"email" json:"email"`
+	TokenHash       string             `bson:"tokenHash" json:"-"`
+	TokenLookupHash string             `bson:"tokenLookupHash" json:"-"`
+	CreatedAt       time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+type userStore struct {
+	collection *mongo.Collection
+}
+
+func newUserStore(collection *mongo.Collection) *userStore {
+	s := &userStore{collection: collection}
+	s.ensureIndexes()
+	return s
+}
+
+// ensureIndexes creates the unique index on email so a race between two
+// concurrent registrations can't create duplicate accounts; create turns
+// the resulting duplicate-key error into ErrEmailTaken.
+// It also indexes tokenLookupHash so authenticate can find the candidate
+// user in O(1) instead of scanning every row in the collection.
+func (s *userStore) ensureIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := s.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "tokenLookupHash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	})
+	if err != nil {
+		logger.Error().Err(err).Msg("creating unique indexes on users collection")
+	}
+}
+
+// create inserts a new user with a freshly minted token and returns the
+// raw token, which is only ever available at mint time. Fails with
+// ErrEmailTaken if the email is already registered.
+func (s *userStore) create(ctx context.Context, email string) (User, string, error) {
+	token, tokenHash, err := mintToken()
+	if err != nil {
+		return User{}, "", err
+	}
+
+	user := User{Email: email, TokenHash: tokenHash, TokenLookupHash: tokenLookupHash(token), CreatedAt: time.Now()}
+	result, err := s.collection.InsertOne(ctx, user)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return User{}, "", ErrEmailTaken
+		}
+		return User{}, "", err
+	}
+	user.ID = result.InsertedID.(primitive.ObjectID)
+	return user, token, nil
+}
+
+// rotate mints a new token for the user identified by id, invalidating
+// whatever token they currently hold. Callers must have already proven
+// ownership of the account (see tokenHandler) before calling this.
+func (s *userStore) rotate(ctx context.Context, id primitive.ObjectID) (string, error) {
+	token, tokenHash, err := mintToken()
+	if err != nil {
+		return "", err
+	}
+
+	result, err := s.collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"tokenHash": tokenHash, "tokenLookupHash": tokenLookupHash(token)}})
+	if err != nil {
+		return "", err
+	}
+	if result.MatchedCount == 0 {
+		return "", ErrNotFound
+	}
+	return token, nil
+}
+
+// authenticate looks up the user whose token hash matches the given bearer
+// token. Bcrypt hashes aren't queryable directly (each is salted), so this
+// finds the candidate by the indexed tokenLookupHash - an O(1) exact match -
+// and only then runs the (deliberately slow) bcrypt compare to confirm it,
+// rather than bcrypt-comparing against every user in the collection.
+func (s *userStore) authenticate(ctx context.Context, token string) (User, error) {
+	var user User
+	err := s.collection.FindOne(ctx, bson.M{"tokenLookupHash": tokenLookupHash(token)}).Decode(&user)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return User{}, ErrNotFound
+		}
+		return User{}, err
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.TokenHash), []byte(token)) != nil {
+		return User{}, ErrNotFound
+	}
+	return user, nil
+}
+
+// mintToken generates a 32-byte random bearer token and its bcrypt hash.
+func mintToken() (token string, tokenHash string, err error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	token = hex.EncodeToString(raw)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(token), bcrypt.DefaultCost)
+	if err != nil {
+		return "", "", err
+	}
+	return token, string(hash), nil
+}
+
+// tokenLookupHash returns a fast, indexed sha256 hash of token so
+// authenticate can find the candidate user without scanning the collection.
+// It's only used to narrow the search; bcrypt still makes the real
+// comparison.
+func tokenLookupHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// registerHandler creates a new user and returns their initial bearer token.
+func registerHandler(users *userStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body struct {
+			Email string `json:"email" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.RequestTimeout)
+		defer cancel()
+
+		user, token, err := users.create(ctx, body.Email)
+		if err != nil {
+			if errors.Is(err, ErrEmailTaken) {
+				c.JSON(http.StatusConflict, gin.H{"error": "email already registered"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"user": user, "token": token})
+	}
+}
+
+// tokenHandler mints a fresh bearer token for the caller, invalidating
+// their current one. Sits behind authMiddleware so a caller must already
+// present a valid bearer token to prove they own the account being
+// rotated - minting a token from the email alone would let anyone take
+// over any account whose email they know.
+func tokenHandler(users *userStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := c.MustGet("user").(User)
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.RequestTimeout)
+		defer cancel()
+
+		token, err := users.rotate(ctx, user.ID)
+		if err != nil {
+			if err == ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"token": token})
+	}
+}
+
+// authMiddleware requires a valid "Authorization: Bearer <token>" header and
+// attaches the authenticated User to the request context as "user".
+func authMiddleware(users *userStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		token := strings.TrimPrefix(header, "Bearer ")
+		if token == "" || token == header {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.RequestTimeout)
+		defer cancel()
+
+		user, err := users.authenticate(ctx, token)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid bearer token"})
+			return
+		}
+
+		c.Set("user", user)
+		c.Next()
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestAuthMiddlewareMissingToken(t *testing.T) {
+	store := &userStore{}
+
+	r := gin.New()
+	r.GET("/protected", authMiddleware(store), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestAuthMiddlewareTokenFlow(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("valid token authenticates and attaches the user", func(mt *mtest.T) {
+		token, hash, err := mintToken()
+		if err != nil {
+			t.Fatalf("mintToken: %v", err)
+		}
+
+		id := primitive.NewObjectID()
+		first := mtest.CreateCursorResponse(1, "test.users", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: id},
+			{Key: "email", Value: "alice@example.com"},
+			{Key: "tokenHash", Value: hash},
+		})
+		killCursors := mtest.CreateCursorResponse(0, "test.users", mtest.NextBatch)
+		mt.AddMockResponses(first, killCursors)
+
+		store := &userStore{collection: mt.Coll}
+
+		r := gin.New()
+		r.GET("/protected", authMiddleware(store), func(c *gin.Context) {
+			u := c.MustGet("user").(User)
+			c.JSON(http.StatusOK, gin.H{"email": u.Email})
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d (body: %s)", w.Code, http.StatusOK, w.Body.String())
+		}
+	})
+
+	mt.Run("unknown token is rejected", func(mt *mtest.T) {
+		empty := mtest.CreateCursorResponse(0, "test.users", mtest.FirstBatch)
+		mt.AddMockResponses(empty)
+
+		store := &userStore{collection: mt.Coll}
+
+		r := gin.New()
+		r.GET("/protected", authMiddleware(store), func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"ok": true})
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("Authorization", "Bearer not-a-real-token")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+		}
+	})
+}
+
+func TestRegisterHandlerRejectsDuplicateEmail(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("duplicate email is rejected with 409", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{
+			Code:    11000,
+			Message: "E11000 duplicate key error collection: test.users index: email_1",
+		}))
+
+		store := &userStore{collection: mt.Coll}
+
+		r := gin.New()
+		r.POST("/auth/register", registerHandler(store))
+
+		req := httptest.NewRequest(http.MethodPost, "/auth/register", strings.NewReader(`{"email":"alice@example.com"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusConflict {
+			t.Fatalf("status = %d, want %d (body: %s)", w.Code, http.StatusConflict, w.Body.String())
+		}
+	})
+}